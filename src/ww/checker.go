@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+
+	"ww/internal/diff"
+)
+
+// Checker holds everything needed to fetch, compare and report on a single
+// zone, so the one-shot and -daemon code paths run the exact same logic.
+type Checker struct {
+	Zone        string
+	Protocol    string
+	WhoisServer string
+	RDAPServer  string
+	Notifier    Notifier
+	From        string
+	To          []string
+
+	differ   *diff.Differ
+	baseline map[string]map[string]bool
+}
+
+// NewChecker builds a Checker whose baseline is seeded from expectFile and
+// whose comparison rules come from diffConfigFile. expectFile must name a
+// readable file; diffConfigFile may be "", which falls back to plain
+// set-membership comparison with no ignored fields.
+func NewChecker(zone, protocol, whoisServer, rdapServer string, notifier Notifier, from string, to []string, expectFile, diffConfigFile string) (*Checker, error) {
+	expected, err := ioutil.ReadFile(expectFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file %s: %s", expectFile, err)
+	}
+
+	cfg, err := diff.LoadConfig(diffConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := split(expected)
+	log.Printf("Loaded %d fields from %s", len(baseline), expectFile)
+
+	return &Checker{
+		Zone:        zone,
+		Protocol:    protocol,
+		WhoisServer: whoisServer,
+		RDAPServer:  rdapServer,
+		Notifier:    notifier,
+		From:        from,
+		To:          to,
+		differ:      diff.New(cfg),
+		baseline:    baseline,
+	}, nil
+}
+
+// Baseline returns the record the Checker currently compares against.
+func (c *Checker) Baseline() map[string]map[string]bool {
+	return c.baseline
+}
+
+// SetBaseline overrides the record the Checker compares against, e.g. with
+// a daemon's persisted state from a prior run.
+func (c *Checker) SetBaseline(b map[string]map[string]bool) {
+	c.baseline = b
+}
+
+// Check fetches the zone's current record, diffs it against the Checker's
+// baseline, advances the baseline to what was just fetched - so a daemon
+// doesn't keep re-alerting on a change it has already reported - and
+// returns the Changes found.
+func (c *Checker) Check() ([]diff.Change, error) {
+	got, err := fetch(c.Protocol, c.WhoisServer, c.RDAPServer, c.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := c.differ.Diff(c.baseline, got)
+	c.baseline = got
+	return changes, nil
+}
+
+// Notify sends changes, if there are any, to the Checker's recipients via
+// its Notifier.
+func (c *Checker) Notify(changes []diff.Change) {
+	if len(changes) == 0 {
+		return
+	}
+	if err := c.Notifier.Send(c.From, c.Zone, renderReport(changes), c.To); err != nil {
+		log.Printf("Error notifying for %s: %s", c.Zone, err)
+	}
+}
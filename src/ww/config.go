@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	"ww/internal/diff"
+)
+
+// ZoneConfig describes one zone to monitor: its own WHOIS/RDAP server,
+// baseline, diff rules and recipients. SMTP and From fall back to Config's
+// top-level values when left empty, so a Config with many zones sharing
+// one mail setup need not repeat it.
+type ZoneConfig struct {
+	Zone       string   `json:"zone"`
+	Protocol   string   `json:"protocol"`
+	Whois      string   `json:"whois"`
+	RDAP       string   `json:"rdap"`
+	Expect     string   `json:"expect"`
+	DiffConfig string   `json:"diff_config"`
+	From       string   `json:"from"`
+	To         []string `json:"to"`
+
+	Notifier     string `json:"notifier"`
+	SMTP         string `json:"smtp"`
+	SMTPUser     string `json:"smtp_user"`
+	SMTPPassword string `json:"smtp_password"`
+	SendmailPath string `json:"sendmail_path"`
+	WebhookURL   string `json:"webhook_url"`
+}
+
+// Config is a multi-zone ww configuration, replacing the single-zone flag
+// surface for anyone monitoring more than one domain. Despite the example
+// filename "ww.yaml" that's circulated in some notes, -config only ever
+// parses JSON; point it at a .yaml file and LoadConfig will fail to parse.
+type Config struct {
+	From    string       `json:"from"`
+	Digest  bool         `json:"digest"` // one email for all zones instead of one per zone
+	Workers int          `json:"workers"`
+	Zones   []ZoneConfig `json:"zones"`
+
+	Notifier     string `json:"notifier"`
+	SMTP         string `json:"smtp"`
+	SMTPUser     string `json:"smtp_user"`
+	SMTPPassword string `json:"smtp_password"`
+	SendmailPath string `json:"sendmail_path"`
+	WebhookURL   string `json:"webhook_url"`
+}
+
+// LoadConfig reads a multi-zone Config from a JSON (not YAML) file.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading config %s: %s", path, err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %s", path, err)
+	}
+	if len(cfg.Zones) == 0 {
+		return nil, fmt.Errorf("config %s declares no zones", path)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+
+	return &cfg, nil
+}
+
+// checker builds a Checker for one zone of cfg, filling in the zone's own
+// settings over Config's top-level defaults.
+func (cfg *Config) checker(z ZoneConfig) (*Checker, error) {
+	protocol := z.Protocol
+	if protocol == "" {
+		protocol = "whois"
+	}
+	from := z.From
+	if from == "" {
+		from = cfg.From
+	}
+
+	notifier, err := cfg.notifier(z)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewChecker(z.Zone, protocol, z.Whois, z.RDAP, notifier, from,
+		z.To, z.Expect, z.DiffConfig)
+}
+
+// notifier builds the Notifier for one zone of cfg, filling in the zone's
+// own settings over Config's top-level defaults.
+func (cfg *Config) notifier(z ZoneConfig) (Notifier, error) {
+	kind := z.Notifier
+	if kind == "" {
+		kind = cfg.Notifier
+	}
+	smtpServer := z.SMTP
+	if smtpServer == "" {
+		smtpServer = cfg.SMTP
+	}
+	smtpUser := z.SMTPUser
+	if smtpUser == "" {
+		smtpUser = cfg.SMTPUser
+	}
+	smtpPassword := z.SMTPPassword
+	if smtpPassword == "" {
+		smtpPassword = cfg.SMTPPassword
+	}
+	sendmailPath := z.SendmailPath
+	if sendmailPath == "" {
+		sendmailPath = cfg.SendmailPath
+	}
+	webhookURL := z.WebhookURL
+	if webhookURL == "" {
+		webhookURL = cfg.WebhookURL
+	}
+
+	return newNotifier(kind, smtpServer, smtpUser, smtpPassword, sendmailPath, webhookURL)
+}
+
+// runZonesOnce checks every zone in cfg concurrently, bounded by
+// cfg.Workers, and either emails each zone its own changes or - if
+// cfg.Digest is set - sends a single combined digest.
+func runZonesOnce(cfg *Config) {
+	type result struct {
+		checker *Checker
+		changes []diff.Change
+	}
+
+	jobs := make(chan ZoneConfig)
+	results := make(chan result, len(cfg.Zones))
+
+	var wg sync.WaitGroup
+	for i := 0; i < cfg.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for z := range jobs {
+				c, err := cfg.checker(z)
+				if err != nil {
+					log.Printf("%s", err)
+					continue
+				}
+
+				changes, err := c.Check()
+				if err != nil {
+					log.Printf("Error checking %s: %s", c.Zone, err)
+					continue
+				}
+
+				logChanges(changes)
+				results <- result{checker: c, changes: changes}
+			}
+		}()
+	}
+
+	go func() {
+		for _, z := range cfg.Zones {
+			jobs <- z
+		}
+		close(jobs)
+	}()
+
+	wg.Wait()
+	close(results)
+
+	if !cfg.Digest {
+		for r := range results {
+			r.checker.Notify(r.changes)
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	recipients := make(map[string]bool)
+	for r := range results {
+		if len(r.changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "== %s ==\n%s\n", r.checker.Zone, renderReport(r.changes))
+		for _, to := range r.checker.To {
+			recipients[to] = true
+		}
+	}
+	if buf.Len() == 0 {
+		return
+	}
+
+	to := make([]string, 0, len(recipients))
+	for t := range recipients {
+		to = append(to, t)
+	}
+
+	notifier, err := newNotifier(cfg.Notifier, cfg.SMTP, cfg.SMTPUser,
+		cfg.SMTPPassword, cfg.SendmailPath, cfg.WebhookURL)
+	if err != nil {
+		log.Printf("%s", err)
+		return
+	}
+
+	if err := notifier.Send(cfg.From, "multiple zones", buf.String(), to); err != nil {
+		log.Printf("Error sending digest: %s", err)
+	}
+}
+
+// runZonesDaemon runs every zone in cfg as its own polling loop, sharing a
+// single metrics set and - if listen is set - a single /metrics and
+// /healthz listener, instead of one per zone.
+func runZonesDaemon(cfg *Config, interval, jitter time.Duration, stateDir, listen string) {
+	m := newMetrics()
+	if listen != "" {
+		serveMetrics(m, listen)
+	}
+
+	var wg sync.WaitGroup
+	for _, z := range cfg.Zones {
+		c, err := cfg.checker(z)
+		if err != nil {
+			log.Printf("%s", err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(c *Checker) {
+			defer wg.Done()
+			daemonLoop(c, interval, jitter, stateDir, m)
+		}(c)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"ww/internal/diff"
+)
+
+// daemon runs c forever, polling every interval (plus or minus jitter) and
+// backing off exponentially on transient fetch errors. It persists c's
+// baseline to stateDir after every successful check, and - if listen is
+// set - serves /metrics and /healthz.
+func daemon(c *Checker, interval, jitter time.Duration, stateDir, listen string) {
+	m := newMetrics()
+
+	if listen != "" {
+		serveMetrics(m, listen)
+	}
+
+	daemonLoop(c, interval, jitter, stateDir, m)
+}
+
+// serveMetrics starts the /metrics and /healthz HTTP handlers for m on
+// listen in the background.
+func serveMetrics(m *metrics, listen string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m)
+	mux.HandleFunc("/healthz", healthz)
+	go func() {
+		if err := http.ListenAndServe(listen, mux); err != nil {
+			log.Printf("Error serving metrics on %s: %s", listen, err)
+		}
+	}()
+}
+
+// daemonLoop polls c forever, recording every check against m, without
+// starting its own metrics server - used directly when several zones share
+// one /metrics listener. It checks immediately on entry - so starting or
+// restarting the daemon doesn't wait out a full -interval before its first
+// check - and after a transient fetch error it retries after backoff alone
+// rather than backoff plus the normal interval.
+func daemonLoop(c *Checker, interval, jitter time.Duration, stateDir string, m *metrics) {
+	if err := loadState(c, stateDir); err != nil {
+		log.Printf("%s", err)
+	}
+
+	backoff := initialBackoff(interval)
+	for {
+		changes, err := c.Check()
+		m.record(c.Zone, len(changes) > 0, err, time.Now())
+
+		if err != nil {
+			log.Printf("Error checking %s: %s", c.Zone, err)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff, interval)
+			continue
+		}
+		backoff = initialBackoff(interval)
+
+		logChanges(changes)
+		c.Notify(changes)
+
+		if err := saveState(c, stateDir); err != nil {
+			log.Printf("%s", err)
+		}
+
+		time.Sleep(sleepFor(interval, jitter))
+	}
+}
+
+// sleepFor returns interval adjusted by a random offset in [-jitter,
+// +jitter), so that many zones polled on the same interval don't all hit
+// their WHOIS/RDAP servers at once.
+func sleepFor(interval, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	offset := time.Duration(rand.Int63n(int64(2*jitter))) - jitter
+	return interval + offset
+}
+
+// minBackoff is the smallest base a retry backoff is ever allowed to start
+// from, regardless of how short -interval is set to.
+const minBackoff = time.Second
+
+// initialBackoff returns the backoff to use for the first retry after a
+// transient fetch failure: a small fraction of interval rather than interval
+// itself, so a single blip retries again within seconds or minutes instead
+// of waiting out a multiple of the poll interval.
+func initialBackoff(interval time.Duration) time.Duration {
+	backoff := interval / 10
+	if backoff < minBackoff {
+		backoff = minBackoff
+	}
+	return backoff
+}
+
+// nextBackoff doubles backoff, capped at ten times interval, for
+// exponential backoff after a transient fetch failure.
+func nextBackoff(backoff, interval time.Duration) time.Duration {
+	max := interval * 10
+	backoff *= 2
+	if backoff > max {
+		backoff = max
+	}
+	return backoff
+}
+
+// logChanges writes each Change to the log, matching ww's historical
+// behavior of logging every difference as it's found.
+func logChanges(changes []diff.Change) {
+	for _, c := range changes {
+		log.Print(c)
+	}
+}
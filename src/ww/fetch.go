@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+)
+
+// fetch retrieves the current record for zone using the configured
+// protocol and returns it parsed into the same map[string]map[string]bool
+// shape produced by split, regardless of whether the record came from
+// WHOIS or RDAP.
+//
+// protocol is one of "whois", "rdap" or "auto". For "whois" whoisServer is
+// dialled directly. For "rdap" rdapServer is queried directly. For "auto"
+// the IANA RDAP bootstrap file is consulted to find the authoritative RDAP
+// server for zone's TLD and that server is queried.
+func fetch(protocol, whoisServer, rdapServer, zone string) (map[string]map[string]bool, error) {
+	switch protocol {
+	case "whois":
+		response, err := fetchWhois(whoisServer, zone)
+		if err != nil {
+			return nil, err
+		}
+		return split(response), nil
+	case "rdap":
+		if rdapServer == "" {
+			return nil, fmt.Errorf("the -rdap parameter is required when -protocol=rdap")
+		}
+		return queryRDAP(rdapServer, zone)
+	case "auto":
+		server, err := bootstrapRDAP(zone)
+		if err != nil {
+			return nil, err
+		}
+		return queryRDAP(server, zone)
+	}
+
+	return nil, fmt.Errorf("unknown -protocol %q", protocol)
+}
+
+// fetchWhois dials a port-43 WHOIS server and returns its raw response for
+// zone.
+func fetchWhois(server, zone string) ([]byte, error) {
+	c, err := net.Dial("tcp", server)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %s", server, err)
+	}
+
+	fmt.Fprintf(c, "%s\r\n", zone)
+	response, err := ioutil.ReadAll(c)
+	c.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error reading from %s: %s", server, err)
+	}
+
+	return response, nil
+}
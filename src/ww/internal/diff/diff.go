@@ -0,0 +1,263 @@
+// Package diff compares two WHOIS/RDAP field maps (as produced by ww's
+// split or RDAP parsing) subject to a per-field rule set, and reports the
+// differences as a list of structured Changes rather than ad-hoc log
+// lines. Rules let a zone's config silence or normalize the fields that
+// change on every poll - timestamps, DB-refresh banners and the like -
+// which is what makes running ww on a cron tolerable.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Change kinds.
+const (
+	Count        = "count"
+	Missing      = "missing"
+	Extra        = "extra"
+	ValueMissing = "value_missing"
+	ValueExtra   = "value_extra"
+	Changed      = "changed"
+)
+
+// Change describes a single difference found between two field maps.
+type Change struct {
+	Kind  string
+	Field string
+	Old   string
+	New   string
+}
+
+// String renders a Change as a single human-readable line, in the same
+// wording ww has always used in its alert emails.
+func (c Change) String() string {
+	switch c.Kind {
+	case Count:
+		return fmt.Sprintf("Field count different: %s %s", c.Old, c.New)
+	case Missing:
+		return fmt.Sprintf("Field %s required but missing", c.Field)
+	case Extra:
+		return fmt.Sprintf("Extra field %s with value %s", c.Field, c.New)
+	case ValueMissing:
+		return fmt.Sprintf("Field %s expected value [%s] missing", c.Field, c.Old)
+	case ValueExtra:
+		return fmt.Sprintf("Field %s extra value [%s]", c.Field, c.New)
+	case Changed:
+		return fmt.Sprintf("Field %s changed from [%s] to [%s]", c.Field, c.Old, c.New)
+	}
+	return fmt.Sprintf("%s: %s", c.Kind, c.Field)
+}
+
+// Rule describes how a single field should be compared: whether it should
+// be ignored entirely, how its values should be normalized before
+// comparison, and whether it is a scalar field that must match exactly or
+// a set of values compared by membership.
+type Rule struct {
+	Field         string `json:"field"`
+	Ignore        bool   `json:"ignore"`
+	Lowercase     bool   `json:"lowercase"`
+	CollapseSpace bool   `json:"collapse_space"`
+	Normalize     string `json:"normalize"`      // regexp whose matches are replaced
+	NormalizeWith string `json:"normalize_with"` // replacement text, default ""
+	Exact         bool   `json:"exact"`           // compare as a single value, not a set
+
+	normalizeRe *regexp.Regexp
+}
+
+// normalize applies CollapseSpace, Lowercase and Normalize, in that order,
+// to a single value.
+func (r Rule) normalize(v string) string {
+	if r.CollapseSpace {
+		v = strings.Join(strings.Fields(v), " ")
+	}
+	if r.Lowercase {
+		v = strings.ToLower(v)
+	}
+	if r.normalizeRe != nil {
+		v = r.normalizeRe.ReplaceAllString(v, r.NormalizeWith)
+	}
+	return v
+}
+
+// normalizeSet applies normalize to every value in m, returning a new set.
+func (r Rule) normalizeSet(m map[string]bool) map[string]bool {
+	out := make(map[string]bool, len(m))
+	for v := range m {
+		out[r.normalize(v)] = true
+	}
+	return out
+}
+
+// Config is a set of per-field Rules, checked into git alongside a zone's
+// baseline so reviewers can see exactly which fields are being ignored or
+// normalized and why. It's loaded from JSON, not YAML, despite "-diff-config
+// rules.yaml" being a natural name to reach for.
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadConfig reads a Config from a JSON file. An empty path returns an
+// empty Config, so a Differ built from it behaves like plain set-membership
+// comparison with no ignored fields.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		return &Config{}, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading diff config %s: %s", path, err)
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing diff config %s: %s", path, err)
+	}
+
+	for i := range cfg.Rules {
+		r := &cfg.Rules[i]
+		if r.Normalize == "" {
+			continue
+		}
+		re, err := regexp.Compile(r.Normalize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid normalize regexp for field %s: %s", r.Field, err)
+		}
+		r.normalizeRe = re
+	}
+
+	return &cfg, nil
+}
+
+// Differ compares two field maps subject to a Config's per-field rules.
+type Differ struct {
+	rules map[string]Rule
+}
+
+// New builds a Differ from cfg. A nil cfg behaves like an empty one.
+func New(cfg *Config) *Differ {
+	d := &Differ{rules: make(map[string]Rule)}
+	if cfg != nil {
+		for _, r := range cfg.Rules {
+			d.rules[r.Field] = r
+		}
+	}
+	return d
+}
+
+// countIgnoring counts the fields in m that aren't configured Ignore, so a
+// flaky optional field that's merely absent on one fetch doesn't trip the
+// Count change even though its own per-field diff is already suppressed.
+func (d *Differ) countIgnoring(m map[string]map[string]bool) int {
+	n := 0
+	for k := range m {
+		if !d.rule(k).Ignore {
+			n++
+		}
+	}
+	return n
+}
+
+func (d *Differ) rule(field string) Rule {
+	if r, ok := d.rules[field]; ok {
+		return r
+	}
+	return Rule{Field: field}
+}
+
+// sortedJoin renders a value set as a stable, comparable string, used to
+// compare Exact fields as a single scalar rather than by membership.
+func sortedJoin(m map[string]bool) string {
+	vs := make([]string, 0, len(m))
+	for v := range m {
+		vs = append(vs, v)
+	}
+	sort.Strings(vs)
+	return strings.Join(vs, ", ")
+}
+
+// keys flattens a value set into a space-separated string, matching ww's
+// historical "Extra field" wording.
+func keys(m map[string]bool) string {
+	vs := make([]string, 0, len(m))
+	for v := range m {
+		vs = append(vs, v)
+	}
+	return strings.Join(vs, " ")
+}
+
+// Normalize applies each field's normalization rule to m, returning a new
+// map. It's used to turn a freshly fetched record into the canonical form
+// that gets written out as a baseline snapshot, so a later Diff against it
+// only reports differences the rules weren't already going to normalize
+// away.
+func (d *Differ) Normalize(m map[string]map[string]bool) map[string]map[string]bool {
+	out := make(map[string]map[string]bool, len(m))
+	for k, v := range m {
+		out[k] = d.rule(k).normalizeSet(v)
+	}
+	return out
+}
+
+// Diff compares old (the expected/baseline record) against got (what was
+// just fetched), both in the map[string]map[string]bool shape produced by
+// ww's split or RDAP parsing, and returns the Changes between them after
+// applying d's per-field ignore, normalization and exact-match rules.
+func (d *Differ) Diff(old, got map[string]map[string]bool) []Change {
+	var changes []Change
+
+	if n0, n1 := d.countIgnoring(old), d.countIgnoring(got); n0 != n1 {
+		changes = append(changes, Change{Kind: Count,
+			Old: fmt.Sprintf("%d", n0), New: fmt.Sprintf("%d", n1)})
+	}
+
+	for k, m0 := range old {
+		r := d.rule(k)
+		if r.Ignore {
+			continue
+		}
+		m0 = r.normalizeSet(m0)
+
+		m1, ok := got[k]
+		if !ok {
+			changes = append(changes, Change{Kind: Missing, Field: k})
+			continue
+		}
+		m1 = r.normalizeSet(m1)
+
+		if r.Exact {
+			if o, n := sortedJoin(m0), sortedJoin(m1); o != n {
+				changes = append(changes, Change{Kind: Changed, Field: k, Old: o, New: n})
+			}
+			continue
+		}
+
+		for v := range m0 {
+			if _, ok := m1[v]; !ok {
+				changes = append(changes, Change{Kind: ValueMissing, Field: k, Old: v})
+			}
+		}
+		for v := range m1 {
+			if _, ok := m0[v]; !ok {
+				changes = append(changes, Change{Kind: ValueExtra, Field: k, New: v})
+			}
+		}
+	}
+
+	for k, v := range got {
+		r := d.rule(k)
+		if r.Ignore {
+			continue
+		}
+		if _, ok := old[k]; !ok {
+			changes = append(changes, Change{Kind: Extra, Field: k, New: keys(r.normalizeSet(v))})
+		}
+	}
+
+	return changes
+}
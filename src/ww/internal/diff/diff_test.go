@@ -0,0 +1,186 @@
+package diff
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func fieldMap(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+func kinds(changes []Change) []string {
+	ks := make([]string, len(changes))
+	for i, c := range changes {
+		ks[i] = c.Kind + ":" + c.Field
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func TestDiffNoRulesIdentical(t *testing.T) {
+	d := New(nil)
+	m := map[string]map[string]bool{
+		"Domain Name": fieldMap("EXAMPLE.COM"),
+	}
+	if changes := d.Diff(m, m); len(changes) != 0 {
+		t.Errorf("Diff(m, m) = %v, want no changes", changes)
+	}
+}
+
+func TestDiffMissingAndExtra(t *testing.T) {
+	d := New(nil)
+	old := map[string]map[string]bool{
+		"Registrant": fieldMap("Jane Doe"),
+	}
+	got := map[string]map[string]bool{
+		"Registrar": fieldMap("Example Registrar"),
+	}
+
+	changes := d.Diff(old, got)
+	want := []string{Extra + ":Registrar", Missing + ":Registrant"}
+	if ks := kinds(changes); !equalStrings(ks, want) {
+		t.Errorf("Diff() kinds = %v, want %v", ks, want)
+	}
+}
+
+func TestDiffValueMissingAndExtra(t *testing.T) {
+	d := New(nil)
+	old := map[string]map[string]bool{
+		"Name Server": fieldMap("NS1.EXAMPLE.COM", "NS2.EXAMPLE.COM"),
+	}
+	got := map[string]map[string]bool{
+		"Name Server": fieldMap("NS1.EXAMPLE.COM", "NS3.EXAMPLE.COM"),
+	}
+
+	changes := d.Diff(old, got)
+	want := []string{ValueExtra + ":Name Server", ValueMissing + ":Name Server"}
+	if ks := kinds(changes); !equalStrings(ks, want) {
+		t.Errorf("Diff() kinds = %v, want %v", ks, want)
+	}
+}
+
+func TestDiffIgnoreRuleSuppressesFieldAndCount(t *testing.T) {
+	cfg := &Config{Rules: []Rule{{Field: "Updated Date", Ignore: true}}}
+	d := New(cfg)
+
+	old := map[string]map[string]bool{
+		"Domain Name":  fieldMap("EXAMPLE.COM"),
+		"Updated Date": fieldMap("2024-01-01T00:00:00Z"),
+	}
+	got := map[string]map[string]bool{
+		"Domain Name": fieldMap("EXAMPLE.COM"),
+		// Updated Date flaps on every poll, and is absent entirely this time.
+	}
+
+	if changes := d.Diff(old, got); len(changes) != 0 {
+		t.Errorf("Diff() = %v, want no changes (Updated Date should be ignored)", changes)
+	}
+}
+
+func TestDiffCountChangeIgnoresIgnoredFields(t *testing.T) {
+	cfg := &Config{Rules: []Rule{{Field: "Updated Date", Ignore: true}}}
+	d := New(cfg)
+
+	old := map[string]map[string]bool{
+		"Domain Name":  fieldMap("EXAMPLE.COM"),
+		"Registrant":   fieldMap("Jane Doe"),
+		"Updated Date": fieldMap("2024-01-01T00:00:00Z"),
+	}
+	// got is missing Registrant for real, but also happens to omit the
+	// ignored Updated Date field - only the former should count.
+	got := map[string]map[string]bool{
+		"Domain Name": fieldMap("EXAMPLE.COM"),
+	}
+
+	changes := d.Diff(old, got)
+	var counts, missing int
+	for _, c := range changes {
+		switch c.Kind {
+		case Count:
+			counts++
+			if c.Old != "2" || c.New != "1" {
+				t.Errorf("Count change = %s -> %s, want 2 -> 1 (ignored field excluded)", c.Old, c.New)
+			}
+		case Missing:
+			missing++
+		}
+	}
+	if counts != 1 {
+		t.Errorf("got %d Count changes, want exactly 1", counts)
+	}
+	if missing != 1 {
+		t.Errorf("got %d Missing changes, want exactly 1", missing)
+	}
+}
+
+func TestDiffExactRule(t *testing.T) {
+	cfg := &Config{Rules: []Rule{{Field: "Domain Status", Exact: true}}}
+	d := New(cfg)
+
+	old := map[string]map[string]bool{
+		"Domain Status": fieldMap("clientTransferProhibited", "clientUpdateProhibited"),
+	}
+	got := map[string]map[string]bool{
+		// Same set, different insertion/membership order - Exact compares the
+		// sorted, joined string so this must not report a Changed field.
+		"Domain Status": fieldMap("clientUpdateProhibited", "clientTransferProhibited"),
+	}
+	if changes := d.Diff(old, got); len(changes) != 0 {
+		t.Errorf("Diff() = %v, want no changes for reordered exact-match set", changes)
+	}
+
+	got["Domain Status"] = fieldMap("clientTransferProhibited")
+	changes := d.Diff(old, got)
+	if len(changes) != 1 || changes[0].Kind != Changed || changes[0].Field != "Domain Status" {
+		t.Errorf("Diff() = %v, want a single Changed Domain Status", changes)
+	}
+}
+
+func TestDiffNormalizeRule(t *testing.T) {
+	cfg := &Config{Rules: []Rule{{
+		Field:     "Registrar",
+		Lowercase: true,
+	}}}
+	d := New(cfg)
+
+	old := map[string]map[string]bool{"Registrar": fieldMap("Example Registrar, LLC")}
+	got := map[string]map[string]bool{"Registrar": fieldMap("EXAMPLE REGISTRAR, LLC")}
+
+	if changes := d.Diff(old, got); len(changes) != 0 {
+		t.Errorf("Diff() = %v, want no changes once both sides are lowercased", changes)
+	}
+}
+
+func TestDiffRegexNormalize(t *testing.T) {
+	rule := Rule{
+		Field:     "Updated Date",
+		Normalize: `T\d{2}:\d{2}:\d{2}Z$`,
+	}
+	rule.normalizeRe = regexp.MustCompile(rule.Normalize)
+	d := New(&Config{Rules: []Rule{rule}})
+
+	old := map[string]map[string]bool{"Updated Date": fieldMap("2024-01-01T10:00:00Z")}
+	got := map[string]map[string]bool{"Updated Date": fieldMap("2024-01-01T22:17:05Z")}
+
+	if changes := d.Diff(old, got); len(changes) != 0 {
+		t.Errorf("Diff() = %v, want time-of-day difference normalized away", changes)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// metrics tracks the Prometheus counters/gauges ww exposes in -daemon
+// mode, keyed per zone.
+type metrics struct {
+	mu                 sync.Mutex
+	checksTotal        map[string]int64
+	changesTotal       map[string]int64
+	whoisErrorsTotal   map[string]int64
+	lastCheckTimestamp map[string]float64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		checksTotal:        make(map[string]int64),
+		changesTotal:       make(map[string]int64),
+		whoisErrorsTotal:   make(map[string]int64),
+		lastCheckTimestamp: make(map[string]float64),
+	}
+}
+
+// record updates zone's counters after one check. changed reports whether
+// the check found any Changes; err is the error the check returned, if
+// any.
+func (m *metrics) record(zone string, changed bool, err error, when time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.checksTotal[zone]++
+	if changed {
+		m.changesTotal[zone]++
+	}
+	if err != nil {
+		m.whoisErrorsTotal[zone]++
+	}
+	m.lastCheckTimestamp[zone] = float64(when.Unix())
+}
+
+// ServeHTTP renders the current counters in the Prometheus text exposition
+// format.
+func (m *metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP ww_checks_total Total number of checks performed, per zone")
+	fmt.Fprintln(w, "# TYPE ww_checks_total counter")
+	for zone, v := range m.checksTotal {
+		fmt.Fprintf(w, "ww_checks_total{zone=%q} %d\n", zone, v)
+	}
+
+	fmt.Fprintln(w, "# HELP ww_changes_total Total number of checks that found a change, per zone")
+	fmt.Fprintln(w, "# TYPE ww_changes_total counter")
+	for zone, v := range m.changesTotal {
+		fmt.Fprintf(w, "ww_changes_total{zone=%q} %d\n", zone, v)
+	}
+
+	fmt.Fprintln(w, "# HELP ww_whois_errors_total Total number of failed checks, per zone")
+	fmt.Fprintln(w, "# TYPE ww_whois_errors_total counter")
+	for zone, v := range m.whoisErrorsTotal {
+		fmt.Fprintf(w, "ww_whois_errors_total{zone=%q} %d\n", zone, v)
+	}
+
+	fmt.Fprintln(w, "# HELP ww_last_check_timestamp Unix timestamp of the last check, per zone")
+	fmt.Fprintln(w, "# TYPE ww_last_check_timestamp gauge")
+	for zone, v := range m.lastCheckTimestamp {
+		fmt.Fprintf(w, "ww_last_check_timestamp{zone=%q} %g\n", zone, v)
+	}
+}
+
+// healthz always reports ok: ww considers itself healthy as long as its
+// daemon loop is running and scheduling checks, regardless of whether
+// those checks are currently succeeding (see ww_whois_errors_total for
+// that).
+func healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
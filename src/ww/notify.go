@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Notifier delivers a rendered report for zone to its recipients. ww's
+// original smtp.SendMail call - with a nil Auth and no TLS - only ever
+// worked against an open local relay; these implementations cover what a
+// real deployment actually needs to get the alert delivered.
+type Notifier interface {
+	Send(from, zone, body string, to []string) error
+}
+
+// newNotifier builds the Notifier named by kind ("smtp" if kind is ""),
+// using whichever of the remaining arguments it needs.
+func newNotifier(kind, smtpServer, smtpUser, smtpPassword, sendmailPath, webhookURL string) (Notifier, error) {
+	switch kind {
+	case "", "smtp":
+		return &smtpNotifier{Server: smtpServer}, nil
+	case "starttls":
+		return &starttlsNotifier{Server: smtpServer, Username: smtpUser, Password: smtpPassword}, nil
+	case "sendmail":
+		if sendmailPath == "" {
+			sendmailPath = "/usr/sbin/sendmail"
+		}
+		return &sendmailNotifier{Path: sendmailPath}, nil
+	case "webhook":
+		if webhookURL == "" {
+			return nil, fmt.Errorf("the -webhook-url parameter is required for -notifier webhook")
+		}
+		return &webhookNotifier{URL: webhookURL}, nil
+	}
+
+	return nil, fmt.Errorf("unknown -notifier %q", kind)
+}
+
+// buildEmail renders the RFC 5322 message that the email-based Notifiers
+// send, preserving ww's historical header and subject line.
+func buildEmail(from string, to []string, zone, body string) string {
+	header := fmt.Sprintf(`From: %s
+To: %s
+Date: %s
+Subject: WARNING! Change in %s whois record
+
+`, from, strings.Join(to, ", "), time.Now().Format(time.RFC822Z), zone)
+
+	return header + body
+}
+
+// smtpNotifier is ww's original delivery path: an unauthenticated,
+// unencrypted net/smtp.SendMail call. It only works against a local relay
+// that accepts mail without a login, such as a sendmail-backed MTA
+// listening on the given host:port.
+type smtpNotifier struct {
+	Server string
+}
+
+func (n *smtpNotifier) Send(from, zone, body string, to []string) error {
+	return smtp.SendMail(n.Server, nil, from, to, []byte(buildEmail(from, to, zone, body)))
+}
+
+// starttlsNotifier delivers mail to an SMTP server that requires STARTTLS
+// and SASL authentication (PLAIN or LOGIN), which is what every hosted
+// mail provider worth using requires.
+type starttlsNotifier struct {
+	Server   string
+	Username string
+	Password string
+}
+
+func (n *starttlsNotifier) Send(from, zone, body string, to []string) error {
+	host, _, err := net.SplitHostPort(n.Server)
+	if err != nil {
+		return fmt.Errorf("invalid -smtp server %s: %s", n.Server, err)
+	}
+
+	c, err := smtp.Dial(n.Server)
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %s", n.Server, err)
+	}
+	defer c.Close()
+
+	ok, _ := c.Extension("STARTTLS")
+	if !ok {
+		return fmt.Errorf("%s does not advertise STARTTLS; refusing to send credentials or mail over a plaintext connection", n.Server)
+	}
+	if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+		return fmt.Errorf("error starting TLS with %s: %s", n.Server, err)
+	}
+
+	if n.Username != "" {
+		auth, err := n.auth(c)
+		if err != nil {
+			return err
+		}
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("error authenticating with %s: %s", n.Server, err)
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := c.Rcpt(addr); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write([]byte(buildEmail(from, to, zone, body))); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// auth picks a SASL mechanism the server advertises, preferring PLAIN over
+// LOGIN since net/smtp implements it natively.
+func (n *starttlsNotifier) auth(c *smtp.Client) (smtp.Auth, error) {
+	ok, mechs := c.Extension("AUTH")
+	if !ok {
+		return nil, fmt.Errorf("%s does not advertise SMTP AUTH", n.Server)
+	}
+
+	host, _, _ := net.SplitHostPort(n.Server)
+	switch {
+	case strings.Contains(mechs, "PLAIN"):
+		return smtp.PlainAuth("", n.Username, n.Password, host), nil
+	case strings.Contains(mechs, "LOGIN"):
+		return &loginAuth{username: n.Username, password: n.Password}, nil
+	}
+
+	return nil, fmt.Errorf("%s does not support PLAIN or LOGIN auth (%s)", n.Server, mechs)
+}
+
+// loginAuth implements the AUTH LOGIN SASL mechanism. net/smtp only ships
+// PLAIN and CRAM-MD5, but a number of providers still require LOGIN.
+type loginAuth struct {
+	username, password string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSuffix(string(fromServer), ":")) {
+	case "username":
+		return []byte(a.username), nil
+	case "password":
+		return []byte(a.password), nil
+	}
+	return nil, fmt.Errorf("unexpected LOGIN challenge: %s", fromServer)
+}
+
+// sendmailNotifier pipes the message to a local sendmail(1)-compatible
+// binary, for hosts that already have an MTA configured to relay mail.
+type sendmailNotifier struct {
+	Path string
+}
+
+func (n *sendmailNotifier) Send(from, zone, body string, to []string) error {
+	args := append([]string{"-f", from}, to...)
+	cmd := exec.Command(n.Path, args...)
+	cmd.Stdin = strings.NewReader(buildEmail(from, to, zone, body))
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error running %s: %s: %s", n.Path, err, stderr.String())
+	}
+	return nil
+}
+
+// webhookNotifier posts a Slack/Discord/Mattermost-compatible JSON payload
+// - a top-level "text" field - to a generic incoming webhook URL.
+type webhookNotifier struct {
+	URL string
+}
+
+type webhookPayload struct {
+	Text string `json:"text"`
+}
+
+func (n *webhookNotifier) Send(from, zone, body string, to []string) error {
+	payload := webhookPayload{
+		Text: fmt.Sprintf("WARNING! Change in %s whois record\n\n%s", zone, body),
+	}
+
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %s", err)
+	}
+
+	resp, err := http.Post(n.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("error posting to webhook %s: %s", n.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned %s", n.URL, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// bootstrapURL is the IANA RDAP bootstrap registry for domain name
+// services, as defined by RFC 7484.
+const bootstrapURL = "https://data.iana.org/rdap/dns.json"
+
+// rdapBootstrap is the subset of the IANA bootstrap file format we need:
+// a list of [tlds, servers] entries.
+type rdapBootstrap struct {
+	Services [][][]string `json:"services"`
+}
+
+// bootstrapRDAP fetches the IANA RDAP bootstrap file and returns the base
+// URL of the RDAP server responsible for zone's TLD.
+func bootstrapRDAP(zone string) (string, error) {
+	resp, err := http.Get(bootstrapURL)
+	if err != nil {
+		return "", fmt.Errorf("error fetching RDAP bootstrap file: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error fetching RDAP bootstrap file: %s", resp.Status)
+	}
+
+	var b rdapBootstrap
+	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+		return "", fmt.Errorf("error parsing RDAP bootstrap file: %s", err)
+	}
+
+	return matchBootstrapTLD(b.Services, zone)
+}
+
+// matchBootstrapTLD looks up zone's TLD among services, the [tlds, servers]
+// entries of an IANA bootstrap file, and returns the first server listed for
+// it. Split out of bootstrapRDAP so the matching logic can be tested without
+// a network round trip.
+func matchBootstrapTLD(services [][][]string, zone string) (string, error) {
+	tld := zone
+	if i := strings.LastIndex(zone, "."); i != -1 {
+		tld = zone[i+1:]
+	}
+	tld = strings.ToLower(tld)
+
+	for _, entry := range services {
+		if len(entry) != 2 {
+			continue
+		}
+		for _, t := range entry[0] {
+			if strings.ToLower(t) == tld {
+				if len(entry[1]) == 0 {
+					return "", fmt.Errorf("no RDAP server listed for TLD %s", tld)
+				}
+				return entry[1][0], nil
+			}
+		}
+	}
+
+	return "", fmt.Errorf("no RDAP server found for TLD %s", tld)
+}
+
+// rdapEntity is a vCard-wrapping contact associated with a domain, such as
+// the registrant or the sponsoring registrar.
+type rdapEntity struct {
+	Roles      []string        `json:"roles"`
+	VCardArray json.RawMessage `json:"vcardArray"`
+}
+
+// rdapEvent records an action taken against the domain, such as
+// registration or its most recent update.
+type rdapEvent struct {
+	Action string `json:"eventAction"`
+	Date   string `json:"eventDate"`
+}
+
+// rdapNameserver is a single nameserver entry in an RDAP domain response.
+type rdapNameserver struct {
+	LDHName string `json:"ldhName"`
+}
+
+// rdapDomain is the subset of RFC 7483's domain object that has an
+// equivalent field in classic WHOIS output.
+type rdapDomain struct {
+	LDHName     string           `json:"ldhName"`
+	Status      []string         `json:"status"`
+	Entities    []rdapEntity     `json:"entities"`
+	Events      []rdapEvent      `json:"events"`
+	Nameservers []rdapNameserver `json:"nameservers"`
+}
+
+// rdapEntityField and rdapEventField name the field that a domain's fields
+// map to, keyed by the entity role or event action used in the RDAP response.
+var rdapEntityField = map[string]string{
+	"registrant":     "Registrant",
+	"administrative": "Admin Contact",
+	"technical":      "Tech Contact",
+	"registrar":      "Registrar",
+}
+
+var rdapEventField = map[string]string{
+	"registration": "Creation Date",
+	"expiration":   "Registry Expiry Date",
+	"last changed": "Updated Date",
+	"transfer":     "Transfer Date",
+}
+
+// queryRDAP performs an RDAP domain lookup of zone against server and
+// parses the response into the same map[string]map[string]bool shape that
+// split produces from WHOIS text, so the rest of ww can treat the two
+// protocols identically.
+func queryRDAP(server, zone string) (map[string]map[string]bool, error) {
+	url := strings.TrimRight(server, "/") + "/domain/" + zone
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching %s: %s", url, resp.Status)
+	}
+
+	var d rdapDomain
+	if err := json.NewDecoder(resp.Body).Decode(&d); err != nil {
+		return nil, fmt.Errorf("error parsing RDAP response from %s: %s", url, err)
+	}
+
+	return rdapToFields(&d), nil
+}
+
+// add records v under field k in fields, creating the set for k if this is
+// its first value.
+func add(fields map[string]map[string]bool, k, v string) {
+	if _, ok := fields[k]; !ok {
+		fields[k] = make(map[string]bool)
+	}
+	fields[k][v] = true
+}
+
+// rdapToFields maps an RDAP domain object onto the stable field names used
+// throughout ww, so a diff against a WHOIS-sourced record (or an earlier
+// RDAP-sourced one) compares like with like.
+func rdapToFields(d *rdapDomain) map[string]map[string]bool {
+	fields := make(map[string]map[string]bool)
+
+	if d.LDHName != "" {
+		add(fields, "Domain Name", strings.ToUpper(d.LDHName))
+	}
+
+	for _, s := range d.Status {
+		add(fields, "Domain Status", s)
+	}
+
+	for _, ns := range d.Nameservers {
+		if ns.LDHName != "" {
+			add(fields, "Name Server", strings.ToUpper(ns.LDHName))
+		}
+	}
+
+	for _, e := range d.Entities {
+		name := vCardFullName(e.VCardArray)
+		if name == "" {
+			continue
+		}
+		for _, role := range e.Roles {
+			if field, ok := rdapEntityField[strings.ToLower(role)]; ok {
+				add(fields, field, name)
+			}
+		}
+	}
+
+	for _, e := range d.Events {
+		field, ok := rdapEventField[strings.ToLower(e.Action)]
+		if !ok {
+			continue
+		}
+		add(fields, field, e.Date)
+	}
+
+	return fields
+}
+
+// vCardFullName extracts the "fn" (full name) property from a jCard
+// vcardArray as used by RDAP entities, e.g.
+// ["vcard", [["version", {}, "text", "4.0"], ["fn", {}, "text", "Example
+// Registrar"], ...]]. It returns "" if no name is present.
+func vCardFullName(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var vcard []json.RawMessage
+	if err := json.Unmarshal(raw, &vcard); err != nil || len(vcard) != 2 {
+		return ""
+	}
+
+	var props [][]json.RawMessage
+	if err := json.Unmarshal(vcard[1], &props); err != nil {
+		return ""
+	}
+
+	for _, p := range props {
+		if len(p) < 4 {
+			continue
+		}
+		var name string
+		if err := json.Unmarshal(p[0], &name); err != nil || name != "fn" {
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(p[3], &value); err == nil {
+			return value
+		}
+	}
+
+	return ""
+}
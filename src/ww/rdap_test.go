@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestVCardFullName(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{
+			name: "fn present",
+			raw: `["vcard", [
+				["version", {}, "text", "4.0"],
+				["fn", {}, "text", "Example Registrar"]
+			]]`,
+			want: "Example Registrar",
+		},
+		{
+			name: "no fn property",
+			raw: `["vcard", [
+				["version", {}, "text", "4.0"]
+			]]`,
+			want: "",
+		},
+		{
+			name: "property too short to hold a value",
+			raw: `["vcard", [
+				["fn", {}, "text"]
+			]]`,
+			want: "",
+		},
+		{
+			name: "empty",
+			raw:  "",
+			want: "",
+		},
+		{
+			name: "not a 2-element vcard array",
+			raw:  `["vcard", [], "extra"]`,
+			want: "",
+		},
+		{
+			name: "json object rather than an array",
+			raw:  `{"not": "a vcard"}`,
+			want: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := vCardFullName(json.RawMessage(tc.raw))
+			if got != tc.want {
+				t.Errorf("vCardFullName(%s) = %q, want %q", tc.raw, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRdapToFields(t *testing.T) {
+	d := &rdapDomain{
+		LDHName: "example.com",
+		Status:  []string{"client transfer prohibited"},
+		Entities: []rdapEntity{
+			{
+				Roles:      []string{"registrant"},
+				VCardArray: json.RawMessage(`["vcard", [["fn", {}, "text", "Jane Doe"]]]`),
+			},
+			{
+				Roles:      []string{"technical", "administrative"},
+				VCardArray: json.RawMessage(`["vcard", [["fn", {}, "text", "Support Team"]]]`),
+			},
+			{
+				// No vCardArray - should be skipped rather than recorded as "".
+				Roles: []string{"registrar"},
+			},
+			{
+				// Role with no mapping in rdapEntityField - should be ignored.
+				Roles:      []string{"billing"},
+				VCardArray: json.RawMessage(`["vcard", [["fn", {}, "text", "Billing Contact"]]]`),
+			},
+		},
+		Events: []rdapEvent{
+			{Action: "registration", Date: "2001-01-01T00:00:00Z"},
+			{Action: "last changed", Date: "2024-01-01T00:00:00Z"},
+			{Action: "unmapped action", Date: "2024-06-01T00:00:00Z"},
+		},
+		Nameservers: []rdapNameserver{
+			{LDHName: "ns1.example.com"},
+			{LDHName: ""},
+		},
+	}
+
+	got := rdapToFields(d)
+
+	want := map[string]map[string]bool{
+		"Domain Name":   {"EXAMPLE.COM": true},
+		"Domain Status": {"client transfer prohibited": true},
+		"Name Server":   {"NS1.EXAMPLE.COM": true},
+		"Registrant":    {"Jane Doe": true},
+		"Tech Contact":  {"Support Team": true},
+		"Admin Contact": {"Support Team": true},
+		"Creation Date": {"2001-01-01T00:00:00Z": true},
+		"Updated Date":  {"2024-01-01T00:00:00Z": true},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("rdapToFields() = %d fields, want %d: got %v", len(got), len(want), got)
+	}
+	for field, values := range want {
+		gv, ok := got[field]
+		if !ok {
+			t.Errorf("missing field %q", field)
+			continue
+		}
+		if len(gv) != len(values) {
+			t.Errorf("field %q = %v, want %v", field, gv, values)
+			continue
+		}
+		for v := range values {
+			if !gv[v] {
+				t.Errorf("field %q missing value %q, got %v", field, v, gv)
+			}
+		}
+	}
+}
+
+func TestMatchBootstrapTLD(t *testing.T) {
+	services := [][][]string{
+		{{"com", "net"}, {"https://rdap.verisign.com/com/v1/"}},
+		{{"org"}, {"https://rdap.publicinterestregistry.org/rdap/", "https://rdap.backup.example/"}},
+		{{"empty"}, {}},
+	}
+
+	tests := []struct {
+		zone    string
+		want    string
+		wantErr bool
+	}{
+		{zone: "example.com", want: "https://rdap.verisign.com/com/v1/"},
+		{zone: "example.NET", want: "https://rdap.verisign.com/com/v1/"},
+		{zone: "example.org", want: "https://rdap.publicinterestregistry.org/rdap/"},
+		{zone: "example.empty", wantErr: true},
+		{zone: "example.unknown", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, err := matchBootstrapTLD(services, tc.zone)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("matchBootstrapTLD(%q) = %q, want error", tc.zone, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("matchBootstrapTLD(%q) unexpected error: %s", tc.zone, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("matchBootstrapTLD(%q) = %q, want %q", tc.zone, got, tc.want)
+		}
+	}
+}
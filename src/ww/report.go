@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"text/template"
+
+	"ww/internal/diff"
+)
+
+// reportTmpl renders one line per Change, in the order diff.Differ found
+// them. It is a text/template rather than the string concatenation ww used
+// to do; the template is compiled into the binary, so changing the body
+// still means editing this constant.
+const reportTmpl = `{{range .}}{{.}}
+{{end}}`
+
+var reportTemplate = template.Must(template.New("report").Parse(reportTmpl))
+
+// renderReport renders changes as the body of an alert notification. It
+// returns "" if there is nothing to report, so callers can treat an empty
+// string as "nothing to notify".
+func renderReport(changes []diff.Change) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, changes); err != nil {
+		log.Printf("Error rendering report template: %s", err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"ww/internal/diff"
+)
+
+// cmdSnapshot implements 'ww snapshot', which captures the current,
+// normalized record for a zone as canonical JSON - a stable, reviewable
+// replacement for 'whois ... > expected-output', which breaks the moment
+// the registry reorders lines or changes whitespace.
+func cmdSnapshot(args []string) int {
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	zone := fs.String("zone", "", "The zone to snapshot")
+	protocol := fs.String("protocol", "whois",
+		"Protocol to use to fetch the record: whois, rdap or auto")
+	whois := fs.String("whois", "whois.networksolutions.com:43",
+		"whois server host:port")
+	rdap := fs.String("rdap", "",
+		"RDAP server base URL to query (required when -protocol=rdap)")
+	diffConfig := fs.String("diff-config", "",
+		"Name of JSON (not YAML) file with per-field ignore/normalize rules")
+	out := fs.String("out", "baseline.json", "File to write the snapshot to")
+	update := fs.Bool("update", false,
+		"Diff against the existing -out baseline, log what changed, then overwrite it")
+	fs.Parse(args)
+
+	if *zone == "" {
+		fmt.Println("The -zone parameter is required")
+		return 1
+	}
+
+	got, err := fetch(*protocol, *whois, *rdap, *zone)
+	if err != nil {
+		log.Printf("%s", err)
+		return 1
+	}
+
+	cfg, err := diff.LoadConfig(*diffConfig)
+	if err != nil {
+		log.Printf("%s", err)
+		return 1
+	}
+	d := diff.New(cfg)
+	got = d.Normalize(got)
+
+	if *update {
+		if existing, err := ioutil.ReadFile(*out); err == nil {
+			var baseline map[string]map[string]bool
+			if err := json.Unmarshal(existing, &baseline); err != nil {
+				log.Printf("error parsing existing baseline %s: %s", *out, err)
+				return 1
+			}
+
+			changes := d.Diff(baseline, got)
+			if len(changes) == 0 {
+				fmt.Println("No changes since the last snapshot")
+			} else {
+				fmt.Println("Changes since the last snapshot:")
+				for _, c := range changes {
+					fmt.Println(" ", c)
+				}
+			}
+		}
+	} else if _, err := os.Stat(*out); err == nil {
+		fmt.Printf("%s already exists; pass -update to confirm the change and overwrite it\n", *out)
+		return 1
+	}
+
+	b, err := json.MarshalIndent(got, "", "  ")
+	if err != nil {
+		log.Printf("error encoding snapshot: %s", err)
+		return 1
+	}
+	if err := ioutil.WriteFile(*out, b, 0644); err != nil {
+		log.Printf("error writing %s: %s", *out, err)
+		return 1
+	}
+
+	log.Printf("Wrote %d fields to %s", len(got), *out)
+	return 0
+}
+
+// cmdVerify implements 'ww verify', which fetches a zone's current record
+// and diffs it against a baseline written by 'ww snapshot', optionally
+// notifying on any Changes found.
+func cmdVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	zone := fs.String("zone", "", "The zone to verify")
+	protocol := fs.String("protocol", "whois",
+		"Protocol to use to fetch the record: whois, rdap or auto")
+	whois := fs.String("whois", "whois.networksolutions.com:43",
+		"whois server host:port")
+	rdap := fs.String("rdap", "",
+		"RDAP server base URL to query (required when -protocol=rdap)")
+	diffConfig := fs.String("diff-config", "",
+		"Name of JSON (not YAML) file with per-field ignore/normalize rules")
+	baseline := fs.String("baseline", "baseline.json",
+		"Baseline snapshot file written by 'ww snapshot' to verify against")
+	from := fs.String("from", "", "Email addresses to send from")
+	to := fs.String("to", "",
+		"Comma-separated list of email addresses to notify if the zone has changed")
+	notifierKind := fs.String("notifier", "smtp",
+		"How to deliver alerts: smtp, starttls, sendmail or webhook")
+	smtpServer := fs.String("smtp", "gmail-smtp-in.l.google.com:25",
+		"Address of SMTP server to use (host:port)")
+	smtpUser := fs.String("smtp-user", "", "Username for -notifier starttls")
+	smtpPassword := fs.String("smtp-password", "", "Password for -notifier starttls")
+	sendmailPath := fs.String("sendmail-path", "/usr/sbin/sendmail",
+		"Path to the sendmail(1) binary for -notifier sendmail")
+	webhookURL := fs.String("webhook-url", "", "Webhook URL for -notifier webhook")
+	fs.Parse(args)
+
+	if *zone == "" {
+		fmt.Println("The -zone parameter is required")
+		return 1
+	}
+
+	b, err := ioutil.ReadFile(*baseline)
+	if err != nil {
+		log.Printf("error reading baseline %s: %s", *baseline, err)
+		return 1
+	}
+	var want map[string]map[string]bool
+	if err := json.Unmarshal(b, &want); err != nil {
+		log.Printf("error parsing baseline %s: %s", *baseline, err)
+		return 1
+	}
+
+	got, err := fetch(*protocol, *whois, *rdap, *zone)
+	if err != nil {
+		log.Printf("%s", err)
+		return 1
+	}
+
+	cfg, err := diff.LoadConfig(*diffConfig)
+	if err != nil {
+		log.Printf("%s", err)
+		return 1
+	}
+
+	changes := diff.New(cfg).Diff(want, got)
+	logChanges(changes)
+
+	if len(changes) > 0 && *to != "" {
+		notifier, err := newNotifier(*notifierKind, *smtpServer, *smtpUser,
+			*smtpPassword, *sendmailPath, *webhookURL)
+		if err != nil {
+			log.Printf("%s", err)
+			return 1
+		}
+
+		recipients := strings.Split(*to, ",")
+		if err := notifier.Send(*from, *zone, renderReport(changes), recipients); err != nil {
+			log.Printf("Error notifying for %s: %s", *zone, err)
+		}
+	}
+
+	if len(changes) > 0 {
+		return 1
+	}
+	return 0
+}
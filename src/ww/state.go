@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// stateFileRe matches runs of characters that aren't safe to use unescaped
+// in a file name, so a zone like "example.co.uk" becomes a stable,
+// filesystem-safe state file name.
+var stateFileRe = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// statePath returns the path under dir that a zone's persisted baseline is
+// stored at.
+func statePath(dir, zone string) string {
+	return filepath.Join(dir, stateFileRe.ReplaceAllString(zone, "_")+".json")
+}
+
+// loadState reads a zone's persisted baseline from dir, if one exists, and
+// installs it on c. A missing state file (e.g. on first run) is not an
+// error - the Checker keeps the baseline it was built with.
+func loadState(c *Checker, dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(statePath(dir, c.Zone))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading state for %s: %s", c.Zone, err)
+	}
+
+	var baseline map[string]map[string]bool
+	if err := json.Unmarshal(b, &baseline); err != nil {
+		return fmt.Errorf("error parsing state for %s: %s", c.Zone, err)
+	}
+
+	c.SetBaseline(baseline)
+	return nil
+}
+
+// saveState persists a Checker's current baseline to dir, so that
+// restarting the daemon does not re-alert on changes it has already
+// reported.
+func saveState(c *Checker, dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating state dir %s: %s", dir, err)
+	}
+
+	b, err := json.Marshal(c.Baseline())
+	if err != nil {
+		return fmt.Errorf("error encoding state for %s: %s", c.Zone, err)
+	}
+
+	if err := ioutil.WriteFile(statePath(dir, c.Zone), b, 0644); err != nil {
+		return fmt.Errorf("error writing state for %s: %s", c.Zone, err)
+	}
+
+	return nil
+}
@@ -15,6 +15,19 @@
 //
 // 4. Run ww: 'ww -expect expected-output -zone example.com -whois
 // whois.networksolutions.com:43 -to alert@example.com'
+//
+// As gTLD registries retire port-43 WHOIS in favor of RDAP (RFC 7482/7483),
+// pass '-protocol rdap -rdap https://rdap.example-registry.com/' to query an
+// RDAP server directly, or '-protocol auto' to have ww look up the right
+// RDAP server for the zone's TLD using the IANA bootstrap file.
+//
+// 5. Prefer a reviewable baseline to an -expect file captured by hand: 'ww
+// snapshot -zone example.com -whois whois.networksolutions.com:43 -out
+// baseline.json' writes the canonical, normalized record for the zone, and
+// 'ww verify -zone example.com -baseline baseline.json -to alert@example.com'
+// diffs the current record against it. 'ww snapshot -update' re-captures
+// the baseline after logging what changed, once that change is confirmed
+// expected.
 
 package main
 
@@ -22,10 +35,9 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"net"
-	"net/smtp"
+	"os"
 	"regexp"
 	"strings"
 	"time"
@@ -35,45 +47,15 @@ import (
 
 var fieldRe = regexp.MustCompile(`^((?:[A-Z][A-Za-z]+ ?)+):(.*)$`)
 
-// keys takes a map[string]X and flattens the keys into a space-separated
-// string
-func keys(m map[string]bool) (s string) {
-	for k := range m {
-		s += k
-		s += " "
-	}
-	return
-}
-
-// report adds a message (printf style) to message to be emailed if there are
-// any changes
-func report(msg *string, format string, values... interface{}) {
-	add := fmt.Sprintf(format + "\n", values...)
-	log.Printf(add)
-	*msg += add
-}
-
-// sendReport sends any report of whois differences via email
-func sendReport(server, from, zone, msg string, to []string) {
-	if msg == "" {
-		return
-	}
-
-	t := strings.Join(to, ", ")
-	header := fmt.Sprintf(`From: %s
-To: %s
-Date: %s
-Subject: WARNING! Change in %s whois record
-
-`, from, t, time.Now().Format(time.RFC822Z), zone)
-
-	msg = header + msg
-	err := smtp.SendMail(server, nil, from, to, []byte(msg))
-	if err != nil {
-		log.Printf("Error sending message from %s to %s via %s: %s",
-			from, t, server, err)
-	}
-}
+// footerRe matches the '>>> Label: value <<<' banners some WHOIS servers
+// append after the record proper (e.g. '>>> Last update of whois database:
+// 2024-01-01T00:00:00Z <<<'). They carry a real colon-delimited field, but
+// fieldRe never sees them because they start with '>>> ' rather than a
+// capitalized word, which would otherwise make them impossible to ignore
+// via a diff-config rule. The '>>> ' prefix is kept as part of the field
+// name (e.g. the key is ">>> Last update of whois database"), matching how
+// such fields are written in diff-config rules.
+var footerRe = regexp.MustCompile(`^(>>> [A-Za-z][A-Za-z ]*):(.*?)\s*(?:<<<)?\s*$`)
 
 // split takes the output of whois and splits by lines and then finds the Foo:
 // Bar fields and adds them to a map. Each entry in the map is a map itself so
@@ -84,6 +66,9 @@ func split(b []byte) map[string]map[string]bool {
 	lines := bytes.Split(b, []byte("\n"))
 	for _, l := range lines {
 		m := fieldRe.FindSubmatch(l)
+		if m == nil {
+			m = footerRe.FindSubmatch(l)
+		}
 		if m != nil {
 			k := string(m[1])
 			if _, ok := fields[k]; !ok {
@@ -96,9 +81,53 @@ func split(b []byte) map[string]map[string]bool {
 
 	return fields
 }
+// main dispatches to the 'snapshot' and 'verify' subcommands when given,
+// falling back to ww's original flag-only invocation (which also covers
+// -daemon and -config) otherwise.
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "snapshot":
+			os.Exit(cmdSnapshot(os.Args[2:]))
+		case "verify":
+			os.Exit(cmdVerify(os.Args[2:]))
+		}
+	}
+
+	legacyMain()
+}
+
+func legacyMain() {
 	whois := flag.String("whois", "whois.networksolutions.com:43",
 		"whois server host:port")
+	protocol := flag.String("protocol", "whois",
+		"Protocol to use to fetch the record: whois, rdap or auto")
+	rdap := flag.String("rdap", "",
+		"RDAP server base URL to query (required when -protocol=rdap)")
+	diffConfig := flag.String("diff-config", "",
+		"Name of JSON (not YAML) file with per-field ignore/normalize rules")
+	daemonMode := flag.Bool("daemon", false,
+		"Run continuously, polling on -interval instead of checking once")
+	interval := flag.Duration("interval", time.Hour,
+		"Polling interval in -daemon mode")
+	jitter := flag.Duration("jitter", 5*time.Minute,
+		"Random +/- jitter applied to -interval in -daemon mode")
+	stateDir := flag.String("state-dir", "",
+		"Directory to persist last-seen zone state in -daemon mode")
+	listen := flag.String("listen", "",
+		"Address to serve /metrics and /healthz on in -daemon mode (host:port)")
+	config := flag.String("config", "",
+		"Name of JSON (not YAML) file declaring multiple zones to check, replacing -zone and friends")
+	notifierKind := flag.String("notifier", "smtp",
+		"How to deliver alerts: smtp, starttls, sendmail or webhook")
+	smtpUser := flag.String("smtp-user", "",
+		"Username for -notifier starttls")
+	smtpPassword := flag.String("smtp-password", "",
+		"Password for -notifier starttls")
+	sendmailPath := flag.String("sendmail-path", "/usr/sbin/sendmail",
+		"Path to the sendmail(1) binary for -notifier sendmail")
+	webhookURL := flag.String("webhook-url", "",
+		"Webhook URL for -notifier webhook")
     expect := flag.String("expect", "",
 		"Name of file containing expected output from whois")
 	zone := flag.String("zone", "",
@@ -111,6 +140,21 @@ func main() {
 		"Address of SMTP server to use (host:port)")
 	flag.Parse()
 
+	if *config != "" {
+		cfg, err := LoadConfig(*config)
+		if err != nil {
+			log.Printf("%s", err)
+			return
+		}
+
+		if *daemonMode {
+			runZonesDaemon(cfg, *interval, *jitter, *stateDir, *listen)
+		} else {
+			runZonesOnce(cfg)
+		}
+		return
+	}
+
 	if *expect == "" {
 		fmt.Printf("The -expect parameter is required\n")
 		return
@@ -128,78 +172,58 @@ func main() {
 		return
 	}
 
-	_, _, err := net.SplitHostPort(*whois)
-	if err != nil {
-		fmt.Printf("The -whois parameter must have format host:port: %s\n",
-			err)
+	switch *protocol {
+	case "whois", "rdap", "auto":
+	default:
+		fmt.Printf("The -protocol parameter must be one of whois, rdap or auto\n")
 		return
 	}
-	_, _, err = net.SplitHostPort(*smtpServer)
-	if err != nil {
-		fmt.Printf("The -smtp parameter must have format host:port: %s\n",
-			err)
-		return
+
+	var err error
+	if *protocol == "whois" {
+		_, _, err = net.SplitHostPort(*whois)
+		if err != nil {
+			fmt.Printf("The -whois parameter must have format host:port: %s\n",
+				err)
+			return
+		}
+	}
+	if *notifierKind == "smtp" || *notifierKind == "starttls" {
+		_, _, err = net.SplitHostPort(*smtpServer)
+		if err != nil {
+			fmt.Printf("The -smtp parameter must have format host:port: %s\n",
+				err)
+			return
+		}
 	}
 
 	recipients := strings.Split(*to, ",")
 
-	expected, err := ioutil.ReadFile(*expect)
+	notifier, err := newNotifier(*notifierKind, *smtpServer, *smtpUser,
+		*smtpPassword, *sendmailPath, *webhookURL)
 	if err != nil {
-		fmt.Printf("Error reading file %s: %s\n", *expect, err)
+		log.Printf("%s", err)
 		return
 	}
-	fields := split(expected)
-	log.Printf("Loaded %d fields from %s", len(fields), *expect)
 
-	c, err := net.Dial("tcp", *whois)
+	checker, err := NewChecker(*zone, *protocol, *whois, *rdap, notifier,
+		*from, recipients, *expect, *diffConfig)
 	if err != nil {
-		log.Printf("Error reading from %s: %s", *whois, err)
+		log.Printf("%s", err)
 		return
 	}
-	
-	fmt.Fprintf(c, "%s\r\n", *zone)
-	response, err := ioutil.ReadAll(c)
-	c.Close()
-	if err != nil {
-		log.Printf("Error connecting to %s: %s", *whois, err)
+
+	if *daemonMode {
+		daemon(checker, *interval, *jitter, *stateDir, *listen)
 		return
 	}
 
-	got := split(response)
-			
-	msg := new(string)
-	
-	if len(fields) != len(got) {
-		report(msg, "Field count different: %d %d", len(fields),
-			len(got))
-	}
-	
-	for k, m0 := range fields {
-		if m1, ok := got[k]; !ok {
-			report(msg, "Field %s required but missing", k)
-		} else {
-			for v := range m0 {
-				if _, ok = m1[v]; !ok {
-					report(msg, 
-						"Field %s expected value [%s] missing",
-						k, v)
-				}
-			}
-			
-			for v := range m1 {
-				if _, ok = m0[v]; !ok {
-					report(msg, "Field %s extra value [%s]",
-						k, v)
-				}
-			}
-		}
-	}
-	
-	for k, v := range got {
-		if _, ok := fields[k]; !ok {
-			report(msg, "Extra field %s with value %s", k, keys(v))
-		}
+	changes, err := checker.Check()
+	if err != nil {
+		log.Printf("%s", err)
+		return
 	}
-	
-	sendReport(*smtpServer, *from, *zone, *msg, recipients)
+
+	logChanges(changes)
+	checker.Notify(changes)
 }